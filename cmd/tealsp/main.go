@@ -1,25 +1,36 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"net"
 	"os"
 
-	"github.com/algorand/go-algorand/data/transactions/logic"
 	"github.com/dragmz/teal"
 	"github.com/dragmz/teal/lsp"
 	"github.com/pkg/errors"
+
+	"github.com/algorand/go-algorand/internal/bug"
+	"github.com/algorand/go-algorand/internal/diag"
+	"github.com/algorand/go-algorand/internal/workspace"
 )
 
 type lspArgs struct {
-	Debug string
+	Debug    string
+	Analyses string
 
 	Addr string
 	Net  string
 }
 
 func runLsp(a lspArgs) (int, error) {
+	cfg, err := diag.ResolveConfig(a.Analyses)
+	if err != nil {
+		return -4, errors.Wrap(err, "failed to parse --analyses")
+	}
+
 	var r io.Reader
 	var w io.Writer
 
@@ -44,99 +55,201 @@ func runLsp(a lspArgs) (int, error) {
 		}
 
 		opts = append(opts, lsp.WithDebug(f))
+
+		bugs := make(chan bug.Bug, 16)
+		bug.Notify(bugs)
+		go func() {
+			for b := range bugs {
+				fmt.Fprintf(f, "bug: %s\n", b.Message)
+			}
+		}()
 	}
 
+	opts = append(opts, lsp.WithCommandHandler("teal.listBugs", func(args json.RawMessage) (interface{}, error) {
+		return bug.List(), nil
+	}))
+
+	// As vendored here, WithPrepareDiagnosticsHandler is a synchronous
+	// func(source string) callback invoked serially, one didChange/didOpen at
+	// a time, with no URI, version, or context.Context of its own. There is
+	// never a second call in flight to cancel, so this is a straight,
+	// synchronous call into diag.PrepareWithConfig. Real
+	// cancellation needs the vendored github.com/dragmz/teal/lsp package to
+	// dispatch diagnostics asynchronously and hand this hook a ctx — outside
+	// what this tree can change.
 	opts = append(opts, lsp.WithPrepareDiagnosticsHandler(func(source string) []lsp.LspDiagnostic {
 		var res []lsp.LspDiagnostic
 
-		ops, err := logic.AssembleString(source)
+		for _, d := range diag.PrepareWithConfig(source, cfg) {
+			res = append(res, toLspDiagnostic(d))
+		}
+
+		return res
+	}))
+
+	l, err := lsp.New(r, w, opts...)
+	if err != nil {
+		return -3, errors.Wrap(err, "failed to create lsp")
+	}
+
+	return l.Run()
+}
+
+// toLspDiagnostic converts a diag.Diagnostic, positioned in the assembler's
+// 1-based coordinates, to the 0-based range expected by the LSP protocol.
+func toLspDiagnostic(d diag.Diagnostic) lsp.LspDiagnostic {
+	l := d.Line
+	c := d.Column
+
+	if l != 0 {
+		l--
+	}
+
+	if c != 0 {
+		c--
+	}
+
+	var sev teal.DiagSeverity
+	switch d.Severity {
+	case diag.SeverityWarning:
+		sev = teal.DiagWarn
+	default:
+		sev = teal.DiagErr
+	}
+
+	return lsp.LspDiagnostic{
+		Range: lsp.LspRange{
+			Start: lsp.LspPosition{
+				Line:      l,
+				Character: c,
+			},
+			End: lsp.LspPosition{
+				Line:      l,
+				Character: c,
+			},
+		},
+		Severity: &sev,
+		Source:   d.Source,
+		Message:  d.Message,
+	}
+}
+
+type checkArgs struct {
+	Format   string
+	Analyses string
+	Files    []string
+}
+
+// jsonDiagnostic is the wire shape printed by `teal check --format=json`.
+type jsonDiagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+// runCheck assembles a.Files and the files that transitively #pragma
+// include them, and prints their diagnostics to stdout, either in the
+// grep-friendly "file:line:col: severity: message" form or, if a.Format is
+// "json", as one JSON object per line. Files are loaded into a
+// workspace.Workspace first, so includes resolve against the others passed
+// on the same command line before diag.PrepareWithConfig runs — the same
+// pipeline the LSP handler in runLsp uses, just keyed by real file paths
+// instead of the single document that handler is limited to.
+func runCheck(a checkArgs) (int, error) {
+	cfg, err := diag.ResolveConfig(a.Analyses)
+	if err != nil {
+		return -3, errors.Wrap(err, "failed to parse --analyses")
+	}
 
+	ws := workspace.New()
+	for _, file := range a.Files {
+		source, err := os.ReadFile(file)
 		if err != nil {
-			if len(ops.Errors) == 0 && len(ops.Warnings) == 0 {
-				if err != nil {
-					sev := teal.DiagErr
-					res = append(res, lsp.LspDiagnostic{
-						Range: lsp.LspRange{
-							Start: lsp.LspPosition{
-								Line:      0,
-								Character: 0,
-							},
-							End: lsp.LspPosition{
-								Line:      0,
-								Character: 0,
-							},
-						},
-						Severity: &sev,
-						Message:  err.Error(),
-					})
-				}
-			}
+			return -1, errors.Wrapf(err, "failed to read %s", file)
 		}
 
-		for _, e := range ops.Errors {
-			l := e.Line
-			c := e.Column
+		ws.DidChange(file, string(source))
+	}
 
-			if l != 0 {
-				l--
+	// Diagnose more than just a.Files: if one of them is #pragma included by
+	// another file also passed on this command line, that including file's
+	// diagnostics can change too (e.g. the include now resolves, or no longer
+	// does), so it belongs in this run's output as well. Affected is only
+	// correct once every file's edges are in w.deps, which is why it's
+	// recomputed here rather than using DidChange's own return value above.
+	seen := map[string]bool{}
+	var files []string
+	for _, file := range a.Files {
+		for _, dep := range ws.Affected(file) {
+			if !seen[dep] {
+				seen[dep] = true
+				files = append(files, dep)
 			}
+		}
+	}
 
-			if c != 0 {
-				c--
-			}
+	byFile := ws.Diagnose(files, cfg)
 
-			sev := teal.DiagErr
-			res = append(res, lsp.LspDiagnostic{
-				Range: lsp.LspRange{
-					Start: lsp.LspPosition{
-						Line:      l,
-						Character: c,
-					},
-					End: lsp.LspPosition{
-						Line:      l,
-						Character: c,
-					},
-				},
-				Severity: &sev,
-				Message:  e.Unwrap().Error(),
-			})
-		}
+	code := 0
 
-		for _, w := range ops.Warnings {
-			sev := teal.DiagWarn
-			res = append(res, lsp.LspDiagnostic{
-				Range: lsp.LspRange{
-					Start: lsp.LspPosition{
-						Line:      0,
-						Character: 0,
-					},
-					End: lsp.LspPosition{
-						Line:      0,
-						Character: 0,
-					},
-				},
-				Severity: &sev,
-				Message:  w.Error(),
-			})
+	for _, file := range files {
+		ds := byFile[file]
+		if len(ds) > 0 {
+			code = 1
 		}
 
-		return res
-	}))
+		for _, d := range ds {
+			switch a.Format {
+			case "json":
+				j := jsonDiagnostic{
+					File:     file,
+					Line:     d.Line,
+					Column:   d.Column,
+					Severity: d.Severity.String(),
+					Source:   d.Source,
+					Message:  d.Message,
+				}
 
-	l, err := lsp.New(r, w, opts...)
-	if err != nil {
-		return -3, errors.Wrap(err, "failed to create lsp")
+				b, err := json.Marshal(j)
+				if err != nil {
+					return -2, errors.Wrap(err, "failed to marshal diagnostic")
+				}
+
+				fmt.Println(string(b))
+			default:
+				fmt.Println(d.Format(file))
+			}
+		}
 	}
 
-	return l.Run()
+	return code, nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+
+		format := fs.String("format", "text", "output format: text or json")
+		analyses := fs.String("analyses", "", `JSON teal.analyses config, e.g. {"unused-label":{"enabled":false}}`)
+		fs.Parse(os.Args[2:])
+
+		code, err := runCheck(checkArgs{Format: *format, Analyses: *analyses, Files: fs.Args()})
+		if err != nil {
+			panic(err)
+		}
+
+		os.Exit(code)
+	}
+
 	var a lspArgs
 
 	flag.StringVar(&a.Net, "net", "tcp", "client network")
 	flag.StringVar(&a.Addr, "addr", "", "client address")
 	flag.StringVar(&a.Debug, "debug", "", "debug file path")
+	flag.StringVar(&a.Analyses, "analyses", "", `JSON teal.analyses config, e.g. {"unused-label":{"enabled":false}}`)
 
 	flag.Parse()
 
@@ -0,0 +1,71 @@
+// Package bug provides a minimal, dependency-free way for the TEAL LSP
+// server to record unexpected internal conditions — not diagnostics about
+// the user's TEAL source, but signals that tealsp itself hit a case it
+// didn't expect (for example an assembler error with no accompanying
+// Errors/Warnings to explain it). Modeled on gopls' internal/lsp/bug
+// package: reports are cheap, never panic, and are just as useful attached
+// to a user's issue report as they are streamed to a --debug log.
+package bug
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Bug is a single recorded internal error, in report order.
+type Bug struct {
+	Message string
+}
+
+var (
+	mu    sync.Mutex
+	bugs  []Bug
+	chans []chan<- Bug
+)
+
+// Report records err as a bug. It is a no-op if err is nil, and safe to
+// call from any goroutine.
+func Report(err error) {
+	if err == nil {
+		return
+	}
+
+	report(Bug{Message: err.Error()})
+}
+
+// Reportf is like Report but formats its message like fmt.Sprintf.
+func Reportf(format string, args ...interface{}) {
+	report(Bug{Message: fmt.Sprintf(format, args...)})
+}
+
+func report(b Bug) {
+	mu.Lock()
+	bugs = append(bugs, b)
+	listeners := append([]chan<- Bug(nil), chans...)
+	mu.Unlock()
+
+	for _, c := range listeners {
+		select {
+		case c <- b:
+		default:
+		}
+	}
+}
+
+// List returns every bug recorded so far, oldest first.
+func List() []Bug {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return append([]Bug(nil), bugs...)
+}
+
+// Notify registers c to receive every bug reported from now on. Sends are
+// non-blocking, so a slow or full channel simply misses notifications
+// instead of blocking the reporter.
+func Notify(c chan<- Bug) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	chans = append(chans, c)
+}
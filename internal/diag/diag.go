@@ -0,0 +1,226 @@
+// Package diag centralizes the assembler-diagnostics pipeline shared by the
+// TEAL LSP server and the `teal check` CLI command, so both surfaces agree
+// on what counts as an error or a warning.
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/algorand/go-algorand/data/transactions/logic"
+	"github.com/algorand/go-algorand/internal/bug"
+)
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError marks a diagnostic that prevents assembly from succeeding.
+	SeverityError Severity = iota
+	// SeverityWarning marks a diagnostic that does not prevent assembly.
+	SeverityWarning
+)
+
+// String returns the lower-case name used in CLI output and JSON.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// SourceAssembler identifies a Diagnostic that comes straight from a
+	// failed or erroring assembly (logic.AssembleString's Errors, or an
+	// unexplained top-level error).
+	SourceAssembler = "teal-assembler"
+	// SourceLint identifies a Diagnostic from a configurable check (an
+	// assembler warning or one of the checks in lint.go), i.e. one that a
+	// project can promote, demote, or silence via Config.
+	SourceLint = "teal-lint"
+)
+
+// Diagnostic is a single assembler finding, positioned in the 1-based
+// line/column coordinates reported by the TEAL assembler. Callers that need
+// 0-based LSP coordinates (e.g. textDocument/publishDiagnostics) are
+// responsible for converting.
+type Diagnostic struct {
+	Line     int
+	Column   int
+	Severity Severity
+	Source   string
+	Message  string
+}
+
+// Format renders d in the grep-friendly "file:line:col: severity: message"
+// form used by `teal check`.
+func (d Diagnostic) Format(file string) string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", file, d.Line, d.Column, d.Severity, d.Message)
+}
+
+// AnalysisConfig controls one named check: "assembler-warning",
+// "unused-label", "missing-version-pragma", or "dead-code".
+type AnalysisConfig struct {
+	Enabled  bool
+	Severity Severity
+}
+
+// Config is a teal.analyses-style settings map, keyed by check name. A
+// check absent from Config is enabled at SeverityWarning by default.
+type Config map[string]AnalysisConfig
+
+// DefaultConfig returns the built-in checks at their default enablement and
+// severity.
+func DefaultConfig() Config {
+	return Config{
+		"assembler-warning":      {Enabled: true, Severity: SeverityWarning},
+		"unused-label":           {Enabled: true, Severity: SeverityWarning},
+		"missing-version-pragma": {Enabled: true, Severity: SeverityWarning},
+		"dead-code":              {Enabled: true, Severity: SeverityWarning},
+	}
+}
+
+func (c Config) lookup(name string) AnalysisConfig {
+	if a, ok := c[name]; ok {
+		return a
+	}
+
+	return AnalysisConfig{Enabled: true, Severity: SeverityWarning}
+}
+
+// configEntry is the JSON shape of one Config entry, as delivered by the
+// --analyses flag on either tealsp itself or `teal check`:
+// {"enabled": false, "severity": "error"}, with both fields optional.
+//
+// There is no editor-configurable equivalent yet: that needs an
+// lsp.WithAnalyses option and workspace/configuration (teal.analyses)
+// support from the vendored github.com/dragmz/teal/lsp package, which this
+// tree doesn't have the source to add. Until then, --analyses is the only
+// way to change these defaults.
+type configEntry struct {
+	Enabled  *bool  `json:"enabled"`
+	Severity string `json:"severity"`
+}
+
+// ParseConfig parses a teal.analyses-style JSON object, keyed by check
+// name, into a Config layered on top of DefaultConfig. A check omitted from
+// raw keeps its default enablement and severity.
+func ParseConfig(raw []byte) (Config, error) {
+	var entries map[string]configEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultConfig()
+	for name, e := range entries {
+		a := cfg.lookup(name)
+
+		if e.Enabled != nil {
+			a.Enabled = *e.Enabled
+		}
+
+		switch e.Severity {
+		case "error":
+			a.Severity = SeverityError
+		case "warning":
+			a.Severity = SeverityWarning
+		}
+
+		cfg[name] = a
+	}
+
+	return cfg, nil
+}
+
+// ResolveConfig returns DefaultConfig, or ParseConfig(raw) if raw is
+// non-empty. It's the --analyses flag's resolution rule factored out of
+// cmd/tealsp so runLsp and runCheck don't each reimplement it.
+func ResolveConfig(raw string) (Config, error) {
+	if raw == "" {
+		return DefaultConfig(), nil
+	}
+
+	return ParseConfig([]byte(raw))
+}
+
+// Prepare assembles source and returns its diagnostics under DefaultConfig.
+// It is shorthand for PrepareWithConfig, which both the LSP
+// textDocument/publishDiagnostics path and the `teal check` CLI command
+// call, so the two never drift apart.
+func Prepare(source string) []Diagnostic {
+	return PrepareWithConfig(source, DefaultConfig())
+}
+
+// PrepareWithConfig is Prepare, but maps assembler warnings through cfg so a
+// project can enable/disable or re-sever them (e.g. promote a warning to an
+// error) instead of always getting SeverityWarning.
+func PrepareWithConfig(source string, cfg Config) []Diagnostic {
+	var res []Diagnostic
+
+	ops, err := logic.AssembleString(source)
+	if err != nil {
+		if len(ops.Errors) == 0 && len(ops.Warnings) == 0 {
+			// AssembleString failed but left nothing in Errors/Warnings to
+			// explain why. That's an assembler-internal condition we don't
+			// understand, not a TEAL source problem, so record it as a bug
+			// in addition to surfacing a best-effort diagnostic.
+			bug.Reportf("assembler returned an error with no Errors/Warnings: %v", err)
+
+			res = append(res, Diagnostic{
+				Severity: SeverityError,
+				Source:   SourceAssembler,
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	for _, e := range ops.Errors {
+		msg := e.Error()
+		if u := e.Unwrap(); u != nil {
+			msg = u.Error()
+		}
+
+		res = append(res, Diagnostic{
+			Line:     e.Line,
+			Column:   e.Column,
+			Severity: SeverityError,
+			Source:   SourceAssembler,
+			Message:  msg,
+		})
+	}
+
+	if check := cfg.lookup("assembler-warning"); check.Enabled {
+		for _, w := range ops.Warnings {
+			res = append(res, Diagnostic{
+				Severity: check.Severity,
+				Source:   SourceLint,
+				Message:  w.Error(),
+			})
+		}
+	}
+
+	for _, lint := range []struct {
+		name string
+		run  func(string) []Diagnostic
+	}{
+		{"missing-version-pragma", lintMissingVersionPragma},
+		{"unused-label", lintUnusedLabels},
+		{"dead-code", lintDeadCode},
+	} {
+		check := cfg.lookup(lint.name)
+		if !check.Enabled {
+			continue
+		}
+
+		for _, d := range lint.run(source) {
+			d.Severity = check.Severity
+			res = append(res, d)
+		}
+	}
+
+	return res
+}
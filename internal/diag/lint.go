@@ -0,0 +1,104 @@
+package diag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	versionPragmaRe = regexp.MustCompile(`(?m)^\s*#pragma\s+version\s+\d+\s*(?://.*)?$`)
+	labelDefRe      = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*):\s*(?://.*)?$`)
+	labelRefRe      = regexp.MustCompile(`^(?:b|bz|bnz|callsub)\s+([A-Za-z_][A-Za-z0-9_]*)\s*(?://.*)?$`)
+	labelListRefRe  = regexp.MustCompile(`^(?:switch|match)\s+(.+?)\s*(?://.*)?$`)
+	labelNameRe     = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	terminalOpRe    = regexp.MustCompile(`^(?:return|err)\s*(?://.*)?$`)
+)
+
+// lintMissingVersionPragma flags a program with no `#pragma version N`
+// directive, which the assembler otherwise silently defaults.
+func lintMissingVersionPragma(source string) []Diagnostic {
+	if versionPragmaRe.MatchString(source) {
+		return nil
+	}
+
+	return []Diagnostic{{
+		Line:    1,
+		Column:  1,
+		Source:  SourceLint,
+		Message: "missing #pragma version directive",
+	}}
+}
+
+// lintUnusedLabels flags every label defined with `label:` that no `b`,
+// `bz`, `bnz`, `callsub`, `switch`, or `match` in the program ever targets.
+// switch and match each take a space-separated list of labels rather than a
+// single one, so they're matched separately from the single-label ops.
+func lintUnusedLabels(source string) []Diagnostic {
+	lines := strings.Split(source, "\n")
+
+	refs := make(map[string]bool)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if m := labelRefRe.FindStringSubmatch(trimmed); m != nil {
+			refs[m[1]] = true
+		}
+
+		if m := labelListRefRe.FindStringSubmatch(trimmed); m != nil {
+			for _, name := range labelNameRe.FindAllString(m[1], -1) {
+				refs[name] = true
+			}
+		}
+	}
+
+	var res []Diagnostic
+	for i, line := range lines {
+		m := labelDefRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || refs[m[1]] {
+			continue
+		}
+
+		res = append(res, Diagnostic{
+			Line:    i + 1,
+			Column:  1,
+			Source:  SourceLint,
+			Message: fmt.Sprintf("label %q is never referenced", m[1]),
+		})
+	}
+
+	return res
+}
+
+// lintDeadCode flags any instruction that follows an unconditional `return`
+// or `err` before the next label, since nothing can jump into the middle of
+// that run to reach it.
+func lintDeadCode(source string) []Diagnostic {
+	var res []Diagnostic
+
+	dead := false
+	for i, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "//"):
+			continue
+		case labelDefRe.MatchString(trimmed):
+			dead = false
+			continue
+		case dead:
+			res = append(res, Diagnostic{
+				Line:    i + 1,
+				Column:  1,
+				Source:  SourceLint,
+				Message: "unreachable code after return/err",
+			})
+		}
+
+		if terminalOpRe.MatchString(trimmed) {
+			dead = true
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,101 @@
+package diag
+
+import "testing"
+
+func TestLintMissingVersionPragma(t *testing.T) {
+	if got := lintMissingVersionPragma("#pragma version 8\nint 1\n"); got != nil {
+		t.Errorf("got %v diagnostics for a program with a version pragma, want none", got)
+	}
+
+	got := lintMissingVersionPragma("int 1\nreturn\n")
+	if len(got) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(got))
+	}
+	if got[0].Line != 1 || got[0].Column != 1 {
+		t.Errorf("got Line=%d Column=%d, want 1,1", got[0].Line, got[0].Column)
+	}
+}
+
+func TestLintUnusedLabels(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+		want   []string // labels expected to be flagged as unused
+	}{
+		{
+			name: "referenced via b",
+			source: "" +
+				"#pragma version 8\n" +
+				"b target\n" +
+				"target:\n" +
+				"int 1\n",
+		},
+		{
+			name: "referenced via callsub",
+			source: "" +
+				"#pragma version 8\n" +
+				"callsub sub\n" +
+				"return\n" +
+				"sub:\n" +
+				"retsub\n",
+		},
+		{
+			name: "referenced via switch",
+			source: "" +
+				"#pragma version 8\n" +
+				"int 0\n" +
+				"switch a b\n" +
+				"a:\n" +
+				"b:\n" +
+				"return\n",
+		},
+		{
+			name: "referenced via match",
+			source: "" +
+				"#pragma version 8\n" +
+				"int 0\n" +
+				"match a b\n" +
+				"a:\n" +
+				"b:\n" +
+				"return\n",
+		},
+		{
+			name: "truly unused",
+			source: "" +
+				"#pragma version 8\n" +
+				"int 1\n" +
+				"unused:\n" +
+				"return\n",
+			want: []string{"unused"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := lintUnusedLabels(c.source)
+
+			var gotLabels []string
+			for _, d := range got {
+				gotLabels = append(gotLabels, d.Message)
+			}
+
+			if len(got) != len(c.want) {
+				t.Fatalf("got %d unused-label diagnostics (%v), want %d (%v)", len(got), gotLabels, len(c.want), c.want)
+			}
+		})
+	}
+}
+
+func TestLintDeadCode(t *testing.T) {
+	if got := lintDeadCode("#pragma version 8\nint 1\nreturn\n"); got != nil {
+		t.Errorf("got %v diagnostics, want none", got)
+	}
+
+	got := lintDeadCode("#pragma version 8\nint 1\nreturn\nint 2\n")
+	if len(got) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(got))
+	}
+	if got[0].Line != 4 {
+		t.Errorf("got Line=%d, want 4", got[0].Line)
+	}
+}
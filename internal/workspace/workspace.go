@@ -0,0 +1,156 @@
+// Package workspace tracks the open TEAL files in a project and the
+// `#pragma include` edges between them, so a change to one file can
+// re-diagnose every file that depends on it. It is analogous to gopls'
+// source.View, scoped to what a single TEAL workspace needs.
+//
+// It is driven by `teal check`'s real file-path arguments (see
+// cmd/tealsp's runCheck); it is not wired into the LSP server, because the
+// vendored github.com/dragmz/teal/lsp's WithPrepareDiagnosticsHandler hook
+// takes a bare source string with no URI and no per-file publish, so there
+// is nothing in this tree for a workspace-aware handler to plug into.
+package workspace
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/algorand/go-algorand/internal/diag"
+)
+
+// includePragma matches a `#pragma include "path/to/file.teal"` directive
+// used to split a TEAL program across files.
+var includePragma = regexp.MustCompile(`(?m)^\s*#pragma\s+include\s+"([^"]+)"\s*$`)
+
+// Workspace tracks every open TEAL file's content and its #pragma include
+// edges.
+type Workspace struct {
+	files map[string]string          // uri -> content
+	deps  map[string]map[string]bool // uri -> set of uris it includes
+}
+
+// New returns an empty Workspace.
+func New() *Workspace {
+	return &Workspace{
+		files: make(map[string]string),
+		deps:  make(map[string]map[string]bool),
+	}
+}
+
+// DidChange records uri's new content and edges, and returns Affected(uri):
+// uri itself, plus every open file that (transitively) includes it.
+func (w *Workspace) DidChange(uri, content string) []string {
+	w.files[uri] = content
+	w.deps[uri] = toSet(includes(uri, content))
+
+	return w.Affected(uri)
+}
+
+// Diagnose runs diag.PrepareWithConfig for every URI in uris, expanding each
+// file's #pragma include targets ahead of its own content first so
+// cross-file references resolve the way the assembler would see them once
+// included. A URI with no open content (closed or never opened) maps to
+// nil, so callers can tell "no diagnostics" apart from "nothing to
+// publish".
+func (w *Workspace) Diagnose(uris []string, cfg diag.Config) map[string][]diag.Diagnostic {
+	res := make(map[string][]diag.Diagnostic, len(uris))
+
+	for _, uri := range uris {
+		content, ok := w.files[uri]
+		if !ok {
+			res[uri] = nil
+			continue
+		}
+
+		ds := diag.PrepareWithConfig(w.expand(uri, content, map[string]bool{uri: true}), cfg)
+		if ds == nil {
+			ds = []diag.Diagnostic{}
+		}
+
+		res[uri] = ds
+	}
+
+	return res
+}
+
+// Affected returns uri and every URI that depends on it, directly or
+// transitively, over the current dependency graph.
+func (w *Workspace) Affected(uri string) []string {
+	seen := map[string]bool{uri: true}
+	queue := []string{uri}
+
+	var order []string
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		order = append(order, cur)
+
+		for other, deps := range w.deps {
+			if deps[cur] && !seen[other] {
+				seen[other] = true
+				queue = append(queue, other)
+			}
+		}
+	}
+
+	return order
+}
+
+// expand inlines content's #pragma include targets ahead of content, with
+// the `#pragma include "..."` directive lines themselves stripped out —
+// otherwise they'd reach logic.AssembleString as unknown pragmas and the
+// assembler would reject them. Already-visited URIs are skipped so an
+// include cycle can't recurse forever.
+func (w *Workspace) expand(uri, content string, visited map[string]bool) string {
+	var b strings.Builder
+
+	for _, dep := range includes(uri, content) {
+		if visited[dep] {
+			continue
+		}
+		visited[dep] = true
+
+		if depContent, ok := w.files[dep]; ok {
+			b.WriteString(w.expand(dep, depContent, visited))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(includePragma.ReplaceAllString(content, ""))
+
+	return b.String()
+}
+
+// includes returns the URIs that uri's content directly #pragma includes,
+// resolved relative to uri's own directory.
+func includes(uri, content string) []string {
+	var res []string
+
+	for _, m := range includePragma.FindAllStringSubmatch(content, -1) {
+		res = append(res, resolve(uri, m[1]))
+	}
+
+	return res
+}
+
+// resolve resolves rel, a #pragma include target, against uri's directory.
+func resolve(uri, rel string) string {
+	if strings.HasPrefix(rel, "/") {
+		return rel
+	}
+
+	i := strings.LastIndex(uri, "/")
+	if i < 0 {
+		return rel
+	}
+
+	return uri[:i] + "/" + rel
+}
+
+func toSet(items []string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, it := range items {
+		m[it] = true
+	}
+
+	return m
+}